@@ -0,0 +1,142 @@
+// Copyright 2013 The Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package stringio
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+// ErrMessageTooLarge is returned by ReadMessage and
+// (*MessageReader).ReadMessage when the length prefix decoded from
+// the stream exceeds the caller-supplied maximum.
+var ErrMessageTooLarge = errors.New("stringio: message exceeds maximum size")
+
+// WriteMessage writes s to w as a length-prefixed message: a
+// little-endian base-128 varint (the same encoding used by
+// encoding/binary.Uvarint) giving len(s), followed by the bytes
+// of s. The returned int is the total number of bytes written,
+// including the length prefix, and the error is taken from
+// whichever write first failed.
+func WriteMessage(w io.Writer, s string) (int, error) {
+	var header [binary.MaxVarintLen64]byte
+	hn := binary.PutUvarint(header[:], uint64(len(s)))
+	n, err := w.Write(header[:hn])
+	if err != nil {
+		return n, err
+	}
+	sn, err := Write(w, s)
+	return n + sn, err
+}
+
+// ReadMessage reads a length-prefixed message written by
+// WriteMessage from r and returns its payload as a string. If the
+// decoded length exceeds max, or max is negative, ErrMessageTooLarge
+// is returned without attempting to read the payload. The payload is read
+// with io.ReadFull semantics, so a reader that only delivers a
+// few bytes per call (a pipe or socket, for example) is retried
+// until the full message arrives or a real error occurs.
+func ReadMessage(r io.Reader, max int) (string, error) {
+	size, err := readUvarint(r)
+	if err != nil {
+		return "", err
+	}
+	if max < 0 || size > uint64(max) {
+		return "", ErrMessageTooLarge
+	}
+	buf := getBuffer(int(size))
+	defer putBuffer(buf)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return "", err
+	}
+	return string(buf), nil
+}
+
+// readUvarint decodes a little-endian base-128 varint from r,
+// using r's own io.ByteReader implementation when it has one and
+// falling back to single-byte reads otherwise.
+func readUvarint(r io.Reader) (uint64, error) {
+	br, ok := r.(io.ByteReader)
+	if !ok {
+		br = &byteReader{r}
+	}
+	return binary.ReadUvarint(br)
+}
+
+// byteReader adapts an io.Reader without a ReadByte method to
+// io.ByteReader, as required by encoding/binary.ReadUvarint.
+type byteReader struct {
+	r io.Reader
+}
+
+func (b *byteReader) ReadByte() (byte, error) {
+	var buf [1]byte
+	_, err := io.ReadFull(b.r, buf[:])
+	return buf[0], err
+}
+
+// MessageWriter wraps an io.Writer and writes length-prefixed
+// messages to it exactly as the package-level WriteMessage does,
+// while reusing a single pooled scratch buffer across calls so
+// that high-throughput callers don't allocate per message.
+type MessageWriter struct {
+	w   io.Writer
+	buf []byte
+}
+
+// NewMessageWriter returns a MessageWriter that writes messages to w.
+func NewMessageWriter(w io.Writer) *MessageWriter {
+	return &MessageWriter{w: w, buf: getBuffer(minBucketSize)}
+}
+
+// WriteMessage writes s to the underlying writer, as the
+// package-level WriteMessage does.
+func (mw *MessageWriter) WriteMessage(s string) (int, error) {
+	need := binary.MaxVarintLen64 + len(s)
+	if cap(mw.buf) < need {
+		putBuffer(mw.buf)
+		mw.buf = getBuffer(need)
+	}
+	buf := mw.buf[:need]
+	hn := binary.PutUvarint(buf, uint64(len(s)))
+	copy(buf[hn:], s)
+	return mw.w.Write(buf[:hn+len(s)])
+}
+
+// MessageReader wraps an io.Reader and reads length-prefixed
+// messages from it exactly as the package-level ReadMessage does,
+// while reusing a single pooled scratch buffer across calls so
+// that high-throughput callers don't allocate per message.
+type MessageReader struct {
+	r   io.Reader
+	buf []byte
+}
+
+// NewMessageReader returns a MessageReader that reads messages from r.
+func NewMessageReader(r io.Reader) *MessageReader {
+	return &MessageReader{r: r, buf: getBuffer(minBucketSize)}
+}
+
+// ReadMessage reads the next message from the underlying reader,
+// as the package-level ReadMessage does.
+func (mr *MessageReader) ReadMessage(max int) (string, error) {
+	size, err := readUvarint(mr.r)
+	if err != nil {
+		return "", err
+	}
+	if max < 0 || size > uint64(max) {
+		return "", ErrMessageTooLarge
+	}
+	if cap(mr.buf) < int(size) {
+		putBuffer(mr.buf)
+		mr.buf = getBuffer(int(size))
+	}
+	buf := mr.buf[:size]
+	if _, err := io.ReadFull(mr.r, buf); err != nil {
+		return "", err
+	}
+	return string(buf), nil
+}
@@ -0,0 +1,76 @@
+// Copyright 2013 The Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package stringio
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestWriteMessageReadMessage(t *testing.T) {
+	for _, s := range testStrings {
+		var buf bytes.Buffer
+		if _, err := WriteMessage(&buf, s); err != nil {
+			t.Fatalf("WriteMessage returned error: %v", err)
+		}
+		got, err := ReadMessage(&buf, len(s))
+		if err != nil {
+			t.Fatalf("ReadMessage returned error: %v", err)
+		}
+		if got != s {
+			t.Errorf("ReadMessage returned %q; want %q", got, s)
+		}
+	}
+}
+
+func TestReadMessageTooLarge(t *testing.T) {
+	var buf bytes.Buffer
+	if _, err := WriteMessage(&buf, "hello"); err != nil {
+		t.Fatalf("WriteMessage returned error: %v", err)
+	}
+	if _, err := ReadMessage(&buf, 4); err != ErrMessageTooLarge {
+		t.Errorf("ReadMessage returned %v; want ErrMessageTooLarge", err)
+	}
+}
+
+func TestReadMessageNegativeMax(t *testing.T) {
+	var buf bytes.Buffer
+	if _, err := WriteMessage(&buf, "hello"); err != nil {
+		t.Fatalf("WriteMessage returned error: %v", err)
+	}
+	if _, err := ReadMessage(&buf, -1); err != ErrMessageTooLarge {
+		t.Errorf("ReadMessage returned %v; want ErrMessageTooLarge", err)
+	}
+}
+
+func TestMessageReaderReadMessageNegativeMax(t *testing.T) {
+	var buf bytes.Buffer
+	mw := NewMessageWriter(&buf)
+	mr := NewMessageReader(&buf)
+	if _, err := mw.WriteMessage("hello"); err != nil {
+		t.Fatalf("WriteMessage returned error: %v", err)
+	}
+	if _, err := mr.ReadMessage(-1); err != ErrMessageTooLarge {
+		t.Errorf("ReadMessage returned %v; want ErrMessageTooLarge", err)
+	}
+}
+
+func TestMessageReaderWriter(t *testing.T) {
+	var buf bytes.Buffer
+	mw := NewMessageWriter(&buf)
+	mr := NewMessageReader(&buf)
+	for _, s := range testStrings {
+		if _, err := mw.WriteMessage(s); err != nil {
+			t.Fatalf("WriteMessage returned error: %v", err)
+		}
+		got, err := mr.ReadMessage(len(s))
+		if err != nil {
+			t.Fatalf("ReadMessage returned error: %v", err)
+		}
+		if got != s {
+			t.Errorf("ReadMessage returned %q; want %q", got, s)
+		}
+	}
+}
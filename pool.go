@@ -0,0 +1,150 @@
+// Copyright 2013 The Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package stringio
+
+import "sync"
+
+// The package used to serialize access to a single scratch
+// buffer with a mutex, falling back to a fresh allocation
+// whenever the mutex was already held. Under heavy concurrency
+// that fallback became the common case, so every goroutine paid
+// for an allocation anyway while also paying for the lock
+// attempt.
+//
+// Instead, buffers are drawn from a tiered set of sync.Pools,
+// one per power-of-two size class between minBucketSize and
+// maxBucketSize. getBuffer acquires a buffer of at least the requested
+// capacity from the appropriate pool (allocating on a miss), and
+// putBuffer returns it to the pool matching its capacity. Buffers
+// larger than MaxPooledSize are allocated directly and not
+// pooled, so one oversized call can't pin an outsized buffer in
+// memory indefinitely.
+const (
+	minBucketSize = 64
+	maxBucketSize = 1 << 20 // 1 MiB; the largest bucket the pool ever builds
+)
+
+// MaxPooledSize is the configurable threshold above which putBuffer
+// drops a buffer instead of returning it to the pool, and above
+// which getBuffer allocates directly instead of drawing from a
+// bucket. It defaults to maxBucketSize and can be lowered with
+// SetMaxPooledSize to reclaim memory more aggressively; it cannot
+// be raised past maxBucketSize, since no bucket exists beyond it.
+var MaxPooledSize = maxBucketSize
+
+// SetMaxPooledSize sets MaxPooledSize, clamping it to the range
+// [minBucketSize, maxBucketSize].
+func SetMaxPooledSize(n int) {
+	if n > maxBucketSize {
+		n = maxBucketSize
+	}
+	if n < minBucketSize {
+		n = minBucketSize
+	}
+	MaxPooledSize = n
+}
+
+// buckets[i] holds buffers of size minBucketSize<<i.
+var buckets []sync.Pool
+
+func init() {
+	for size := minBucketSize; size <= maxBucketSize; size <<= 1 {
+		size := size
+		buckets = append(buckets, sync.Pool{
+			New: func() interface{} {
+				return make([]byte, size)
+			},
+		})
+	}
+}
+
+// bucketIndex returns the index into buckets holding buffers of
+// the given size, or -1 if size falls outside the pooled range
+// or exceeds MaxPooledSize.
+func bucketIndex(size int) int {
+	if size < minBucketSize || size > MaxPooledSize {
+		return -1
+	}
+	idx := 0
+	for s := minBucketSize; s < size; s <<= 1 {
+		idx++
+	}
+	return idx
+}
+
+// getBuffer returns a []byte of length n, drawn from the pool
+// bucket sized to at least n bytes. The returned buffer's
+// capacity may exceed n; callers that intend to return it via
+// putBuffer should keep it around (e.g. via defer) rather than
+// reslicing away the extra capacity.
+func getBuffer(n int) []byte {
+	size := leastPowerOfTwoFunc(n)
+	if size < minBucketSize {
+		size = minBucketSize
+	}
+	idx := bucketIndex(size)
+	if idx == -1 {
+		return make([]byte, n, size)
+	}
+	buf := buckets[idx].Get().([]byte)
+	return buf[:n]
+}
+
+// putBuffer returns b to the pool bucket matching cap(b). Buffers
+// too large to belong to any bucket are dropped so they can be
+// garbage collected.
+func putBuffer(b []byte) {
+	c := cap(b)
+	idx := bucketIndex(c)
+	if idx == -1 {
+		return
+	}
+	buckets[idx].Put(b[:c])
+}
+
+// This will be set to the correct
+// function in init()
+var leastPowerOfTwoFunc func(int) int = nil
+
+// Assumes 32-bit integers
+func leastPowerOfTwoGreaterThan_32B(n int) int {
+	// From: http://aggregate.org/MAGIC/#Next%20Largest%20Power%20of%202
+	n-- // make sure it's not already a power of 2
+	n |= (n >> 1)
+	n |= (n >> 2)
+	n |= (n >> 4)
+	n |= (n >> 8)
+	n |= (n >> 16)
+	return n + 1
+}
+
+// Assumes 64-bit integers
+func leastPowerOfTwoGreaterThan_64B(n int) int {
+	// From: http://aggregate.org/MAGIC/#Next%20Largest%20Power%20of%202
+	n-- // make sure it's not already a power of 2
+	n |= (n >> 1)
+	n |= (n >> 2)
+	n |= (n >> 4)
+	n |= (n >> 8)
+	n |= (n >> 16)
+	n |= (n >> 32)
+	return n + 1
+}
+
+// In init, check to see what size
+// integer values are (ie, int32
+// or int64), and set the function
+// pointer to leastPowerOfTwoGreaterThan_XXB
+// appropriately
+func init() {
+	// From: http://stackoverflow.com/a/6878625/836390
+	var MaxInt int = int(^uint(0) >> 1)
+	var Max32BitInt = 0xFFFFFFFF
+	if MaxInt > Max32BitInt {
+		leastPowerOfTwoFunc = leastPowerOfTwoGreaterThan_64B
+	} else {
+		leastPowerOfTwoFunc = leastPowerOfTwoGreaterThan_32B
+	}
+}
@@ -0,0 +1,44 @@
+// Copyright 2013 The Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package stringio
+
+import "testing"
+
+func TestSetMaxPooledSize(t *testing.T) {
+	defer SetMaxPooledSize(maxBucketSize)
+
+	SetMaxPooledSize(128)
+	if MaxPooledSize != 128 {
+		t.Errorf("MaxPooledSize = %d; want 128", MaxPooledSize)
+	}
+
+	// Values above maxBucketSize are clamped down, since no
+	// pool bucket is built beyond it.
+	SetMaxPooledSize(maxBucketSize * 2)
+	if MaxPooledSize != maxBucketSize {
+		t.Errorf("MaxPooledSize = %d; want %d (clamped)", MaxPooledSize, maxBucketSize)
+	}
+
+	// Values below minBucketSize are clamped up.
+	SetMaxPooledSize(0)
+	if MaxPooledSize != minBucketSize {
+		t.Errorf("MaxPooledSize = %d; want %d (clamped)", MaxPooledSize, minBucketSize)
+	}
+}
+
+func TestGetPutBufferRespectsMaxPooledSize(t *testing.T) {
+	defer SetMaxPooledSize(maxBucketSize)
+	SetMaxPooledSize(minBucketSize)
+
+	// A request above the configured max still returns a buffer
+	// of the right length; it's just allocated directly instead
+	// of drawn from (or later returned to) a bucket.
+	n := minBucketSize * 4
+	buf := getBuffer(n)
+	if len(buf) != n {
+		t.Fatalf("getBuffer(%d) returned length %d; want %d", n, len(buf), n)
+	}
+	putBuffer(buf) // must not panic even though it's above MaxPooledSize
+}
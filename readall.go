@@ -0,0 +1,220 @@
+// Copyright 2013 The Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package stringio
+
+import (
+	"errors"
+	"io"
+	"unicode/utf8"
+	"unsafe"
+)
+
+// stringWriter is implemented by writers, such as *bytes.Buffer
+// and *bufio.Writer, that can write a string without an
+// intermediate []byte conversion.
+type stringWriter interface {
+	WriteString(string) (int, error)
+}
+
+// ReadAll reads from r until an error or io.EOF and returns the
+// data it read as a string. A successful call returns err == nil,
+// not err == io.EOF, matching io.ReadAll.
+//
+// Unlike io.ReadAll, which builds a []byte and then converts it
+// to a string (copying the bytes a second time), ReadAll grows
+// its own buffer and then uses unsafe.String to turn it into a
+// string in place. The buffer is not returned to the pool in
+// pool.go, since the returned string continues to reference it.
+func ReadAll(r io.Reader) (string, error) {
+	buf := getBuffer(minBucketSize)
+	buf = buf[:0]
+	for {
+		if len(buf) == cap(buf) {
+			// Force a grow, the same way io.ReadAll does.
+			buf = append(buf, 0)[:len(buf)]
+		}
+		n, err := r.Read(buf[len(buf):cap(buf)])
+		buf = buf[:len(buf)+n]
+		if err != nil {
+			if err == io.EOF {
+				err = nil
+			}
+			return unsafe.String(unsafe.SliceData(buf), len(buf)), err
+		}
+	}
+}
+
+// Copy writes s to w in chunks no larger than maxBucketSize, so
+// that each underlying call to Write draws on the pool's largest
+// bucket instead of growing a single oversized buffer. It returns
+// the number of bytes written, as io.Copy does.
+func Copy(w io.Writer, s string) (int64, error) {
+	var written int64
+	for len(s) > 0 {
+		chunk := s
+		if len(chunk) > maxBucketSize {
+			chunk = chunk[:maxBucketSize]
+		}
+		n, err := Write(w, chunk)
+		written += int64(n)
+		if err != nil {
+			return written, err
+		}
+		s = s[len(chunk):]
+	}
+	return written, nil
+}
+
+// StringReader is a drop-in alternative to strings.NewReader: it
+// wraps a string and implements io.Reader, io.ReaderAt,
+// io.WriterTo, io.ByteScanner, io.RuneScanner, and io.Seeker.
+//
+// Its WriteTo method participates in stringio's buffer pool: if
+// the destination io.Writer doesn't implement WriteString (and so
+// would otherwise force a []byte(s) conversion), WriteTo copies s
+// to it in pooled chunks via Copy instead.
+type StringReader struct {
+	s        string
+	i        int64
+	prevRune int
+}
+
+// NewStringReader returns a new StringReader reading from s.
+func NewStringReader(s string) *StringReader {
+	return &StringReader{s: s, prevRune: -1}
+}
+
+// Len returns the number of bytes of the unread portion of s.
+func (r *StringReader) Len() int {
+	if r.i >= int64(len(r.s)) {
+		return 0
+	}
+	return int(int64(len(r.s)) - r.i)
+}
+
+// Size returns the original length of s.
+func (r *StringReader) Size() int64 { return int64(len(r.s)) }
+
+func (r *StringReader) Read(b []byte) (n int, err error) {
+	if r.i >= int64(len(r.s)) {
+		return 0, io.EOF
+	}
+	r.prevRune = -1
+	n = copy(b, r.s[r.i:])
+	r.i += int64(n)
+	return n, nil
+}
+
+func (r *StringReader) ReadAt(b []byte, off int64) (n int, err error) {
+	if off < 0 {
+		return 0, errors.New("stringio: StringReader.ReadAt: negative offset")
+	}
+	if off >= int64(len(r.s)) {
+		return 0, io.EOF
+	}
+	n = copy(b, r.s[off:])
+	if n < len(b) {
+		err = io.EOF
+	}
+	return n, err
+}
+
+func (r *StringReader) ReadByte() (byte, error) {
+	r.prevRune = -1
+	if r.i >= int64(len(r.s)) {
+		return 0, io.EOF
+	}
+	b := r.s[r.i]
+	r.i++
+	return b, nil
+}
+
+// UnreadByte implements io.ByteScanner by moving the read offset
+// back one byte, undoing the effect of a preceding call to
+// ReadByte. It returns an error only if the reader is at the
+// beginning of the string; it does not check that the most recent
+// read operation was ReadByte.
+func (r *StringReader) UnreadByte() error {
+	if r.i <= 0 {
+		return errors.New("stringio: StringReader.UnreadByte: at beginning of string")
+	}
+	r.prevRune = -1
+	r.i--
+	return nil
+}
+
+func (r *StringReader) ReadRune() (ch rune, size int, err error) {
+	if r.i >= int64(len(r.s)) {
+		r.prevRune = -1
+		return 0, 0, io.EOF
+	}
+	r.prevRune = int(r.i)
+	if c := r.s[r.i]; c < utf8.RuneSelf {
+		r.i++
+		return rune(c), 1, nil
+	}
+	ch, size = utf8.DecodeRuneInString(r.s[r.i:])
+	r.i += int64(size)
+	return ch, size, nil
+}
+
+// UnreadRune implements io.RuneScanner, undoing the effect of the
+// most recent call to ReadRune. It returns an error if the most
+// recent read operation was not ReadRune.
+func (r *StringReader) UnreadRune() error {
+	if r.prevRune < 0 {
+		return errors.New("stringio: StringReader.UnreadRune: previous operation was not ReadRune")
+	}
+	r.i = int64(r.prevRune)
+	r.prevRune = -1
+	return nil
+}
+
+// Seek implements io.Seeker.
+func (r *StringReader) Seek(offset int64, whence int) (int64, error) {
+	r.prevRune = -1
+	var abs int64
+	switch whence {
+	case io.SeekStart:
+		abs = offset
+	case io.SeekCurrent:
+		abs = r.i + offset
+	case io.SeekEnd:
+		abs = int64(len(r.s)) + offset
+	default:
+		return 0, errors.New("stringio: StringReader.Seek: invalid whence")
+	}
+	if abs < 0 {
+		return 0, errors.New("stringio: StringReader.Seek: negative position")
+	}
+	r.i = abs
+	return abs, nil
+}
+
+// WriteTo implements io.WriterTo.
+func (r *StringReader) WriteTo(w io.Writer) (n int64, err error) {
+	r.prevRune = -1
+	if r.i >= int64(len(r.s)) {
+		return 0, nil
+	}
+	s := r.s[r.i:]
+	var m int
+	if sw, ok := w.(stringWriter); ok {
+		m, err = sw.WriteString(s)
+	} else {
+		var m64 int64
+		m64, err = Copy(w, s)
+		m = int(m64)
+	}
+	if m > len(s) {
+		panic("stringio: StringReader.WriteTo: invalid WriteString count")
+	}
+	r.i += int64(m)
+	n = int64(m)
+	if m != len(s) && err == nil {
+		err = io.ErrShortWrite
+	}
+	return n, err
+}
@@ -0,0 +1,262 @@
+// Copyright 2013 The Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package stringio
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestReadAll(t *testing.T) {
+	for _, s := range testStrings {
+		got, err := ReadAll(strings.NewReader(s))
+		if err != nil {
+			t.Errorf("ReadAll returned error: %v", err)
+		} else if got != s {
+			t.Errorf("ReadAll returned %q; want %q", got, s)
+		}
+	}
+}
+
+func TestCopy(t *testing.T) {
+	for _, s := range testStrings {
+		var buf bytes.Buffer
+		n, err := Copy(&buf, s)
+		if err != nil {
+			t.Errorf("Copy returned error: %v", err)
+		} else if n != int64(len(s)) {
+			t.Errorf("Copy wrote %d bytes; want %d", n, len(s))
+		} else if buf.String() != s {
+			t.Errorf("Copy wrote %q; want %q", buf.String(), s)
+		}
+	}
+}
+
+func TestStringReader(t *testing.T) {
+	for _, s := range testStrings {
+		r := NewStringReader(s)
+		got, err := ReadAll(r)
+		if err != nil {
+			t.Errorf("ReadAll(StringReader) returned error: %v", err)
+		} else if got != s {
+			t.Errorf("ReadAll(StringReader) returned %q; want %q", got, s)
+		}
+	}
+}
+
+func TestStringReaderWriteTo(t *testing.T) {
+	for _, s := range testStrings {
+		r := NewStringReader(s)
+		var buf bytes.Buffer
+		n, err := r.WriteTo(&buf)
+		if err != nil {
+			t.Errorf("WriteTo returned error: %v", err)
+		} else if n != int64(len(s)) {
+			t.Errorf("WriteTo wrote %d bytes; want %d", n, len(s))
+		} else if buf.String() != s {
+			t.Errorf("WriteTo wrote %q; want %q", buf.String(), s)
+		}
+	}
+}
+
+func TestStringReaderLenSize(t *testing.T) {
+	const s = "abc 123"
+	r := NewStringReader(s)
+	if r.Size() != int64(len(s)) {
+		t.Errorf("Size() = %d; want %d", r.Size(), len(s))
+	}
+	if r.Len() != len(s) {
+		t.Errorf("Len() = %d; want %d", r.Len(), len(s))
+	}
+	r.Read(make([]byte, 3))
+	if r.Len() != len(s)-3 {
+		t.Errorf("Len() after reading 3 bytes = %d; want %d", r.Len(), len(s)-3)
+	}
+	if r.Size() != int64(len(s)) {
+		t.Errorf("Size() after reading = %d; want %d", r.Size(), len(s))
+	}
+}
+
+func TestStringReaderReadAt(t *testing.T) {
+	const s = "abc 123"
+	r := NewStringReader(s)
+
+	b := make([]byte, 3)
+	n, err := r.ReadAt(b, 2)
+	if err != nil {
+		t.Fatalf("ReadAt returned error: %v", err)
+	}
+	if n != 3 || string(b) != "c 1" {
+		t.Errorf("ReadAt(2) = %d, %q; want 3, %q", n, string(b), "c 1")
+	}
+
+	// ReadAt must not depend on or mutate the reader's read offset.
+	n, err = r.ReadAt(b, 0)
+	if err != nil {
+		t.Fatalf("ReadAt returned error: %v", err)
+	}
+	if n != 3 || string(b[:n]) != "abc" {
+		t.Errorf("ReadAt(0) = %d, %q; want 3, %q", n, string(b[:n]), "abc")
+	}
+
+	// Reading past the end returns io.EOF along with whatever
+	// bytes were available.
+	n, err = r.ReadAt(b, int64(len(s)-1))
+	if err != io.EOF {
+		t.Errorf("ReadAt past end returned error %v; want io.EOF", err)
+	}
+	if n != 1 || string(b[:n]) != s[len(s)-1:] {
+		t.Errorf("ReadAt past end = %d, %q; want 1, %q", n, string(b[:n]), s[len(s)-1:])
+	}
+
+	// Reading at or beyond len(s) returns 0, io.EOF.
+	if n, err := r.ReadAt(b, int64(len(s))); n != 0 || err != io.EOF {
+		t.Errorf("ReadAt(len(s)) = %d, %v; want 0, io.EOF", n, err)
+	}
+
+	// A negative offset is an error.
+	if _, err := r.ReadAt(b, -1); err == nil {
+		t.Errorf("ReadAt(-1) returned nil error; want non-nil")
+	}
+}
+
+func TestStringReaderByteReader(t *testing.T) {
+	const s = "abc"
+	r := NewStringReader(s)
+
+	for i := 0; i < len(s); i++ {
+		b, err := r.ReadByte()
+		if err != nil {
+			t.Fatalf("ReadByte() #%d returned error: %v", i, err)
+		}
+		if b != s[i] {
+			t.Errorf("ReadByte() #%d = %q; want %q", i, b, s[i])
+		}
+	}
+	if _, err := r.ReadByte(); err != io.EOF {
+		t.Errorf("ReadByte() at end returned %v; want io.EOF", err)
+	}
+
+	// UnreadByte should restore the last byte read by ReadByte.
+	r = NewStringReader(s)
+	r.ReadByte()
+	if err := r.UnreadByte(); err != nil {
+		t.Fatalf("UnreadByte() returned error: %v", err)
+	}
+	b, err := r.ReadByte()
+	if err != nil || b != s[0] {
+		t.Errorf("ReadByte() after UnreadByte() = %q, %v; want %q, nil", b, err, s[0])
+	}
+
+	// UnreadByte at the beginning of the string is an error.
+	r = NewStringReader(s)
+	if err := r.UnreadByte(); err == nil {
+		t.Errorf("UnreadByte() at beginning returned nil error; want non-nil")
+	}
+}
+
+func TestStringReaderRuneReader(t *testing.T) {
+	const s = "a日b"
+	want := []rune{'a', '日', 'b'}
+	wantSize := []int{1, 3, 1}
+
+	r := NewStringReader(s)
+	for i, wr := range want {
+		ch, size, err := r.ReadRune()
+		if err != nil {
+			t.Fatalf("ReadRune() #%d returned error: %v", i, err)
+		}
+		if ch != wr || size != wantSize[i] {
+			t.Errorf("ReadRune() #%d = %q, %d; want %q, %d", i, ch, size, wr, wantSize[i])
+		}
+	}
+	if _, _, err := r.ReadRune(); err != io.EOF {
+		t.Errorf("ReadRune() at end returned %v; want io.EOF", err)
+	}
+
+	// UnreadRune should restore the last rune read by ReadRune.
+	r = NewStringReader(s)
+	r.ReadRune()
+	ch, _, _ := r.ReadRune()
+	if ch != '日' {
+		t.Fatalf("ReadRune() #1 = %q; want %q", ch, '日')
+	}
+	if err := r.UnreadRune(); err != nil {
+		t.Fatalf("UnreadRune() returned error: %v", err)
+	}
+	ch, _, err := r.ReadRune()
+	if err != nil || ch != '日' {
+		t.Errorf("ReadRune() after UnreadRune() = %q, %v; want %q, nil", ch, err, '日')
+	}
+
+	// UnreadRune without a preceding ReadRune is an error.
+	r = NewStringReader(s)
+	r.ReadByte()
+	if err := r.UnreadRune(); err == nil {
+		t.Errorf("UnreadRune() after ReadByte() returned nil error; want non-nil")
+	}
+}
+
+func TestStringReaderSeek(t *testing.T) {
+	const s = "abc 123"
+	r := NewStringReader(s)
+
+	if pos, err := r.Seek(3, io.SeekStart); err != nil || pos != 3 {
+		t.Fatalf("Seek(3, SeekStart) = %d, %v; want 3, nil", pos, err)
+	}
+	b, _ := r.ReadByte()
+	if b != s[3] {
+		t.Errorf("byte after Seek(3, SeekStart) = %q; want %q", b, s[3])
+	}
+
+	if pos, err := r.Seek(-2, io.SeekCurrent); err != nil || pos != 2 {
+		t.Fatalf("Seek(-2, SeekCurrent) = %d, %v; want 2, nil", pos, err)
+	}
+	b, _ = r.ReadByte()
+	if b != s[2] {
+		t.Errorf("byte after Seek(-2, SeekCurrent) = %q; want %q", b, s[2])
+	}
+
+	if pos, err := r.Seek(-1, io.SeekEnd); err != nil || pos != int64(len(s))-1 {
+		t.Fatalf("Seek(-1, SeekEnd) = %d, %v; want %d, nil", pos, err, len(s)-1)
+	}
+	b, _ = r.ReadByte()
+	if b != s[len(s)-1] {
+		t.Errorf("byte after Seek(-1, SeekEnd) = %q; want %q", b, s[len(s)-1])
+	}
+
+	if _, err := r.Seek(-1, io.SeekStart); err == nil {
+		t.Errorf("Seek to negative position returned nil error; want non-nil")
+	}
+
+	if _, err := r.Seek(0, 99); err == nil {
+		t.Errorf("Seek with invalid whence returned nil error; want non-nil")
+	}
+}
+
+// noWriteString wraps a bytes.Buffer but hides its WriteString
+// method, forcing StringReader.WriteTo down the Copy path.
+type noWriteString struct {
+	buf bytes.Buffer
+}
+
+func (w *noWriteString) Write(p []byte) (int, error) { return w.buf.Write(p) }
+
+func TestStringReaderWriteToWithoutWriteString(t *testing.T) {
+	for _, s := range testStrings {
+		r := NewStringReader(s)
+		w := new(noWriteString)
+		n, err := r.WriteTo(w)
+		if err != nil {
+			t.Errorf("WriteTo returned error: %v", err)
+		} else if n != int64(len(s)) {
+			t.Errorf("WriteTo wrote %d bytes; want %d", n, len(s))
+		} else if w.buf.String() != s {
+			t.Errorf("WriteTo wrote %q; want %q", w.buf.String(), s)
+		}
+	}
+}
@@ -0,0 +1,100 @@
+// Copyright 2013 The Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package stringio
+
+import (
+	"io"
+	"testing"
+)
+
+// chunkedReader returns at most chunk bytes per Read call, to
+// simulate a reader (a pipe or socket, for example) that delivers
+// a full message only across several short reads.
+type chunkedReader struct {
+	s     string
+	chunk int
+}
+
+func (r *chunkedReader) Read(b []byte) (int, error) {
+	if len(r.s) == 0 {
+		return 0, io.EOF
+	}
+	n := r.chunk
+	if n > len(b) {
+		n = len(b)
+	}
+	if n > len(r.s) {
+		n = len(r.s)
+	}
+	copy(b, r.s[:n])
+	r.s = r.s[n:]
+	return n, nil
+}
+
+type chunkedReaderAt struct {
+	s     string
+	chunk int
+}
+
+func (r *chunkedReaderAt) ReadAt(b []byte, off int64) (int, error) {
+	if off >= int64(len(r.s)) {
+		return 0, io.EOF
+	}
+	rest := r.s[off:]
+	n := r.chunk
+	if n > len(b) {
+		n = len(b)
+	}
+	if n > len(rest) {
+		n = len(rest)
+	}
+	copy(b, rest[:n])
+	if int64(n) < int64(len(b)) && off+int64(n) >= int64(len(r.s)) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+func TestReadFull(t *testing.T) {
+	for _, s := range testStrings {
+		r := &chunkedReader{s: s, chunk: 3}
+		n, got, err := ReadFull(r, len(s))
+		if err != nil {
+			t.Errorf("ReadFull returned error: %v", err)
+		} else if n != len(s) {
+			t.Errorf("ReadFull read %d bytes; want %d", n, len(s))
+		} else if got != s {
+			t.Errorf("ReadFull returned %q; want %q", got, s)
+		}
+	}
+}
+
+func TestReadFullUnexpectedEOF(t *testing.T) {
+	r := &chunkedReader{s: "short", chunk: 2}
+	if _, _, err := ReadFull(r, 100); err != io.ErrUnexpectedEOF {
+		t.Errorf("ReadFull returned error %v; want io.ErrUnexpectedEOF", err)
+	}
+}
+
+func TestReadAtFull(t *testing.T) {
+	for _, s := range testStrings {
+		r := &chunkedReaderAt{s: s, chunk: 3}
+		n, got, err := ReadAtFull(r, len(s), 0)
+		if err != nil {
+			t.Errorf("ReadAtFull returned error: %v", err)
+		} else if n != len(s) {
+			t.Errorf("ReadAtFull read %d bytes; want %d", n, len(s))
+		} else if got != s {
+			t.Errorf("ReadAtFull returned %q; want %q", got, s)
+		}
+	}
+}
+
+func TestReadAtFullUnexpectedEOF(t *testing.T) {
+	r := &chunkedReaderAt{s: "short", chunk: 2}
+	if _, _, err := ReadAtFull(r, 100, 0); err != io.ErrUnexpectedEOF {
+		t.Errorf("ReadAtFull returned error %v; want io.ErrUnexpectedEOF", err)
+	}
+}
@@ -0,0 +1,167 @@
+// Copyright 2013 The Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package stringio
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"unicode/utf8"
+)
+
+// ErrTokenTooLong is returned by ReadLine, ReadUntil, and Peek
+// when a single token would require growing the TokenReader's
+// internal buffer past its configured maximum size.
+var ErrTokenTooLong = errors.New("stringio: token exceeds maximum buffer size")
+
+// TokenReader wraps an io.Reader and reads delimited tokens from
+// it, maintaining an internal pooled buffer so that ReadLine,
+// ReadUntil, and Peek can return strings built directly from the
+// buffer rather than going through bufio's intermediate
+// []byte->string conversion. Unlike bufio.Reader.ReadString, the
+// returned token never includes the delimiter.
+type TokenReader struct {
+	// CRLF, if true (the default set by NewTokenReader), causes
+	// ReadLine to additionally strip a trailing '\r' from each
+	// line, so that both Unix and Windows line endings produce
+	// the same result.
+	CRLF bool
+
+	r       io.Reader
+	buf     []byte
+	r0, w   int
+	maxSize int
+	err     error
+}
+
+// NewTokenReader returns a TokenReader reading from r, with CRLF
+// normalization enabled and a maximum token size of maxBucketSize.
+func NewTokenReader(r io.Reader) *TokenReader {
+	return &TokenReader{
+		CRLF:    true,
+		r:       r,
+		buf:     getBuffer(minBucketSize),
+		maxSize: maxBucketSize,
+	}
+}
+
+// SetMaxTokenSize sets the largest token that ReadLine, ReadUntil,
+// and Peek will buffer before returning ErrTokenTooLong.
+func (t *TokenReader) SetMaxTokenSize(n int) { t.maxSize = n }
+
+// fill reads more data from the underlying reader into buf,
+// compacting already-consumed bytes and growing the buffer first
+// if there's no room.
+func (t *TokenReader) fill() error {
+	if t.r0 > 0 {
+		t.w = copy(t.buf, t.buf[t.r0:t.w])
+		t.r0 = 0
+	}
+	if t.w == len(t.buf) {
+		if len(t.buf) >= t.maxSize {
+			return ErrTokenTooLong
+		}
+		newSize := len(t.buf) * 2
+		if newSize > t.maxSize {
+			newSize = t.maxSize
+		}
+		newBuf := getBuffer(newSize)
+		copy(newBuf, t.buf[:t.w])
+		putBuffer(t.buf)
+		t.buf = newBuf
+	}
+	n, err := t.r.Read(t.buf[t.w:])
+	t.w += n
+	return err
+}
+
+// ReadUntil reads from the underlying reader until delim is
+// found, returning everything up to but not including delim. If
+// the underlying reader returns an error (including io.EOF)
+// before delim is found, ReadUntil returns whatever bytes were
+// accumulated so far alongside that error.
+func (t *TokenReader) ReadUntil(delim byte) (string, error) {
+	for {
+		if idx := bytes.IndexByte(t.buf[t.r0:t.w], delim); idx >= 0 {
+			tok := string(t.buf[t.r0 : t.r0+idx])
+			t.r0 += idx + 1
+			return tok, nil
+		}
+		if t.err != nil {
+			if t.w > t.r0 {
+				tok := string(t.buf[t.r0:t.w])
+				t.r0 = t.w
+				return tok, t.err
+			}
+			return "", t.err
+		}
+		if err := t.fill(); err != nil {
+			t.err = err
+		}
+	}
+}
+
+// ReadLine reads a single '\n'-delimited line from the underlying
+// reader, with the same end-of-stream behavior as ReadUntil. If
+// t.CRLF is true, a trailing '\r' is also stripped from the line.
+func (t *TokenReader) ReadLine() (string, error) {
+	line, err := t.ReadUntil('\n')
+	if t.CRLF && len(line) > 0 && line[len(line)-1] == '\r' {
+		line = line[:len(line)-1]
+	}
+	return line, err
+}
+
+// ReadRune reads a single UTF-8 encoded rune from the underlying
+// reader, returning the rune, its width in bytes, and any error
+// encountered.
+func (t *TokenReader) ReadRune() (rune, int, error) {
+	for {
+		if t.w > t.r0 {
+			c := t.buf[t.r0]
+			if c < utf8.RuneSelf {
+				t.r0++
+				return rune(c), 1, nil
+			}
+			if utf8.FullRune(t.buf[t.r0:t.w]) || t.err != nil {
+				r, size := utf8.DecodeRune(t.buf[t.r0:t.w])
+				t.r0 += size
+				return r, size, nil
+			}
+		}
+		if t.err != nil {
+			return 0, 0, t.err
+		}
+		if err := t.fill(); err != nil {
+			t.err = err
+		}
+	}
+}
+
+// Peek returns the next n bytes as a string without advancing the
+// reader. If fewer than n bytes remain before the underlying
+// reader is exhausted, Peek returns the bytes it has along with
+// the error that stopped it, exactly as ReadUntil does. Peek
+// returns ErrTokenTooLong if n exceeds the reader's maximum token
+// size.
+func (t *TokenReader) Peek(n int) (string, error) {
+	if n > t.maxSize {
+		return "", ErrTokenTooLong
+	}
+	for t.w-t.r0 < n && t.err == nil {
+		if err := t.fill(); err != nil {
+			t.err = err
+		}
+	}
+	avail := t.w - t.r0
+	if avail > n {
+		avail = n
+	}
+	s := string(t.buf[t.r0 : t.r0+avail])
+	if avail < n {
+		return s, t.err
+	}
+	return s, nil
+}
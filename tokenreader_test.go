@@ -0,0 +1,119 @@
+// Copyright 2013 The Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package stringio
+
+import (
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestTokenReaderReadLine(t *testing.T) {
+	tr := NewTokenReader(strings.NewReader("one\r\ntwo\nthree"))
+	want := []string{"one", "two", "three"}
+	for i, w := range want {
+		line, err := tr.ReadLine()
+		if line != w {
+			t.Errorf("line %d: got %q; want %q", i, line, w)
+		}
+		if i < len(want)-1 && err != nil {
+			t.Errorf("line %d: unexpected error: %v", i, err)
+		}
+		if i == len(want)-1 && err != io.EOF {
+			t.Errorf("last line: got error %v; want io.EOF", err)
+		}
+	}
+}
+
+func TestTokenReaderReadUntil(t *testing.T) {
+	tr := NewTokenReader(strings.NewReader("a,bb,ccc"))
+	want := []string{"a", "bb", "ccc"}
+	for i, w := range want {
+		tok, err := tr.ReadUntil(',')
+		if tok != w {
+			t.Errorf("token %d: got %q; want %q", i, tok, w)
+		}
+		if i == len(want)-1 && err != io.EOF {
+			t.Errorf("last token: got error %v; want io.EOF", err)
+		}
+	}
+}
+
+func TestTokenReaderReadRune(t *testing.T) {
+	tr := NewTokenReader(strings.NewReader("a日b"))
+	want := []rune{'a', '日', 'b'}
+	for i, w := range want {
+		r, _, err := tr.ReadRune()
+		if err != nil {
+			t.Fatalf("rune %d: unexpected error: %v", i, err)
+		}
+		if r != w {
+			t.Errorf("rune %d: got %q; want %q", i, r, w)
+		}
+	}
+	if _, _, err := tr.ReadRune(); err != io.EOF {
+		t.Errorf("got error %v; want io.EOF", err)
+	}
+}
+
+func TestTokenReaderPeek(t *testing.T) {
+	tr := NewTokenReader(strings.NewReader("hello world"))
+	peeked, err := tr.Peek(5)
+	if err != nil {
+		t.Fatalf("Peek returned error: %v", err)
+	}
+	if peeked != "hello" {
+		t.Errorf("Peek returned %q; want %q", peeked, "hello")
+	}
+	line, err := tr.ReadUntil(' ')
+	if err != nil {
+		t.Fatalf("ReadUntil returned error: %v", err)
+	}
+	if line != "hello" {
+		t.Errorf("ReadUntil returned %q; want %q", line, "hello")
+	}
+}
+
+func TestTokenReaderTokenTooLong(t *testing.T) {
+	// maxSize is set well above the initial buffer size (64 bytes)
+	// so that ReadUntil must succeed at least one grow before it
+	// runs out of room and returns ErrTokenTooLong.
+	tr := NewTokenReader(strings.NewReader(strings.Repeat("a", 1000)))
+	tr.SetMaxTokenSize(256)
+	if _, err := tr.ReadUntil('\n'); err != ErrTokenTooLong {
+		t.Errorf("got error %v; want ErrTokenTooLong", err)
+	}
+}
+
+// TestTokenReaderGrowAndCompact exercises fill()'s two hand-rolled
+// paths: growing the internal buffer past its initial
+// minBucketSize when a single token doesn't fit, and compacting
+// already-consumed bytes out of the buffer before that grow when
+// r0 > 0. It reads a short line (leaving the reader positioned
+// partway through its buffer) followed by a line far longer than
+// minBucketSize, through a reader that only returns a few bytes
+// per Read call so fill() is exercised repeatedly rather than
+// satisfied in one shot.
+func TestTokenReaderGrowAndCompact(t *testing.T) {
+	long := testStrings[2] // 2049 bytes, comfortably bigger than minBucketSize
+	input := "short\n" + long + "\n"
+	tr := NewTokenReader(&chunkedReader{s: input, chunk: 7})
+
+	line, err := tr.ReadLine()
+	if err != nil {
+		t.Fatalf("first ReadLine returned error: %v", err)
+	}
+	if line != "short" {
+		t.Fatalf("first ReadLine = %q; want %q", line, "short")
+	}
+
+	line, err = tr.ReadLine()
+	if err != nil {
+		t.Fatalf("second ReadLine returned error: %v", err)
+	}
+	if line != long {
+		t.Errorf("second ReadLine returned a %d-byte line; want %d bytes", len(line), len(long))
+	}
+}